@@ -0,0 +1,126 @@
+package kuja
+
+import (
+	"github.com/golang/snappy/snappy"
+	"github.com/plimble/kuja/api"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// serveAPI tries to match req against the API router and, on a match,
+// dispatches it through the same services registered for RPC. It reports
+// whether the request was handled at all, regardless of whether the
+// handler itself errored.
+func (server *Server) serveAPI(w http.ResponseWriter, req *http.Request) bool {
+	ep, params, err := server.apiRouter.Match(req.Method, req.URL.Path)
+	if err != nil {
+		return false
+	}
+
+	parts := strings.SplitN(ep.Name, ".", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	serviceName, methodName := parts[0], parts[1]
+
+	s := server.serviceMap[serviceName]
+	if s == nil {
+		return false
+	}
+	mt := s.method[methodName]
+	if mt == nil {
+		return false
+	}
+
+	ctx := server.pool.Get().(*Ctx)
+	defer server.pool.Put(ctx)
+
+	for name, vals := range req.Header {
+		ctx.ReqMetadata[name] = vals[0]
+	}
+
+	ctx.handlers = s.handlers
+	ctx.mt = mt
+	ctx.req = req
+	ctx.w = w
+	ctx.rcvr = s.rcvr
+	ctx.encoder = server.negotiateEncoder(req)
+	ctx.snappy = req.Header.Get("Content-Encoding") == "snappy"
+	ctx.ServiceID = s.id
+	ctx.ServiceName = serviceName
+	ctx.MethodName = methodName
+
+	if err := serveAPIRequest(ctx, params, req.URL.Query()); err != nil && !ctx.isResp {
+		respError(err, ctx)
+	}
+
+	return true
+}
+
+// serveAPIRequest is serve's counterpart for API-routed requests: besides
+// binding path/query params the router captured, it decodes the body (for
+// methods that carry one) the same way serve does, and honors ctx.snappy on
+// the response so the two dispatch paths behave consistently.
+func serveAPIRequest(ctx *Ctx, params map[string]string, query url.Values) error {
+	argv := reflect.New(ctx.mt.ArgType.Elem())
+	replyv := reflect.New(ctx.mt.ReplyType.Elem())
+
+	if hasBody(ctx.req) {
+		err := ctx.encoder.Decode(ctx.req.Body, argv.Interface())
+		ctx.req.Body.Close()
+		if err != nil && err != io.EOF {
+			return Error(500, "unable to decode request")
+		}
+	}
+
+	if err := api.Bind(argv.Interface(), params, query); err != nil {
+		return Error(500, "unable to bind request")
+	}
+
+	function := ctx.mt.method.Func
+	ctx.returnValues = function.Call([]reflect.Value{ctx.rcvr, ctx.mt.prepareContext(ctx), argv, replyv})
+
+	if ctx.returnValues[0].Interface() != nil {
+		return ctx.returnValues[0].Interface().(error)
+	}
+
+	for name, val := range ctx.RespMetadata {
+		ctx.w.Header().Set(name, val)
+	}
+
+	if ctx.snappy {
+		data, err := ctx.encoder.Marshal(replyv.Interface())
+		if err != nil {
+			return err
+		}
+		data, err = snappy.Encode(nil, data)
+		if err != nil {
+			return err
+		}
+		ctx.isResp = true
+		ctx.w.Header().Set("Snappy", "true")
+		ctx.w.WriteHeader(200)
+		ctx.w.Write(data)
+	} else {
+		ctx.isResp = true
+		ctx.w.WriteHeader(200)
+		ctx.encoder.Encode(ctx.w, replyv.Interface())
+	}
+
+	return nil
+}
+
+// hasBody reports whether req is expected to carry a body to decode, i.e.
+// it's one of the methods api.Endpoint commonly registers for writes. GET,
+// DELETE, and friends are matched by path/query binding alone.
+func hasBody(req *http.Request) bool {
+	switch req.Method {
+	case "POST", "PUT", "PATCH":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"github.com/plimble/kuja/internal/convert"
+	"net/url"
+	"reflect"
+)
+
+var errNotStruct = errors.New("api: v must be a pointer to a struct")
+
+// Bind copies path params and query values into the fields of v tagged
+// `path:"..."` and `query:"..."`. v must be a pointer to a struct.
+func Bind(v interface{}, params map[string]string, query url.Values) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errNotStruct
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		if tag := field.Tag.Get("path"); tag != "" {
+			if val, ok := params[tag]; ok {
+				convert.SetField(rv.Field(i), val)
+			}
+			continue
+		}
+
+		if tag := field.Tag.Get("query"); tag != "" {
+			if val := query.Get(tag); val != "" {
+				convert.SetField(rv.Field(i), val)
+			}
+		}
+	}
+
+	return nil
+}
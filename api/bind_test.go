@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+)
+
+type bindTarget struct {
+	ID   string `path:"id"`
+	Page int    `query:"page"`
+}
+
+func TestBindSetsTaggedFields(t *testing.T) {
+	var v bindTarget
+	err := Bind(&v, map[string]string{"id": "42"}, url.Values{"page": []string{"3"}})
+	if err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if v.ID != "42" || v.Page != 3 {
+		t.Fatalf("got %+v, want ID=42 Page=3", v)
+	}
+}
+
+func TestBindRejectsNonStruct(t *testing.T) {
+	var v string
+	if err := Bind(&v, nil, nil); err != errNotStruct {
+		t.Fatalf("got %v, want errNotStruct", err)
+	}
+}
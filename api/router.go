@@ -0,0 +1,136 @@
+// Package api lets a service declare REST routes for its RPC methods and
+// matches incoming requests against them, independent of how those methods
+// are ultimately invoked.
+package api
+
+import (
+	"errors"
+	"strings"
+)
+
+// Endpoint describes a REST route for an RPC method, e.g. "Foo.List" served
+// at GET /foos/{id}.
+type Endpoint struct {
+	Name   string
+	Method []string
+	Path   []string
+}
+
+// Endpointer is implemented by services that expose REST endpoints
+// alongside their RPC methods, via a companion Endpoints() method.
+type Endpointer interface {
+	Endpoints() []Endpoint
+}
+
+var ErrNotFound = errors.New("api: no route matches")
+
+// route is what a node's endpoints map stores: the matched endpoint plus the
+// placeholder names for this specific registration, in path order. Keeping
+// the names here rather than on the shared trie node lets two endpoints
+// that pass through the same param position use different names (e.g.
+// {id} vs {fooId}) without clobbering each other.
+type route struct {
+	endpoint Endpoint
+	params   []string
+}
+
+type node struct {
+	children   map[string]*node
+	paramChild *node
+	endpoints  map[string]route
+}
+
+func newNode() *node {
+	return &node{
+		children:  make(map[string]*node),
+		endpoints: make(map[string]route),
+	}
+}
+
+// Router matches an HTTP method and path against endpoints registered with
+// Add, compiling {placeholder} path templates into a trie.
+type Router struct {
+	root *node
+}
+
+func NewRouter() *Router {
+	return &Router{root: newNode()}
+}
+
+// Add registers ep under every method/path pair it declares.
+func (r *Router) Add(ep Endpoint) {
+	for _, path := range ep.Path {
+		for _, method := range ep.Method {
+			r.add(method, path, ep)
+		}
+	}
+}
+
+func (r *Router) add(method, path string, ep Endpoint) {
+	n := r.root
+	var params []string
+
+	for _, part := range splitPath(path) {
+		if isParam(part) {
+			if n.paramChild == nil {
+				n.paramChild = newNode()
+			}
+			n = n.paramChild
+			params = append(params, part[1:len(part)-1])
+			continue
+		}
+
+		child, ok := n.children[part]
+		if !ok {
+			child = newNode()
+			n.children[part] = child
+		}
+		n = child
+	}
+
+	n.endpoints[method] = route{endpoint: ep, params: params}
+}
+
+// Match finds the endpoint registered for method and path, returning any
+// {placeholder} values captured from path.
+func (r *Router) Match(method, path string) (Endpoint, map[string]string, error) {
+	n := r.root
+	var values []string
+
+	for _, part := range splitPath(path) {
+		switch {
+		case n.children[part] != nil:
+			n = n.children[part]
+		case n.paramChild != nil:
+			values = append(values, part)
+			n = n.paramChild
+		default:
+			return Endpoint{}, nil, ErrNotFound
+		}
+	}
+
+	rt, ok := n.endpoints[method]
+	if !ok {
+		return Endpoint{}, nil, ErrNotFound
+	}
+
+	params := make(map[string]string, len(rt.params))
+	for i, name := range rt.params {
+		params[name] = values[i]
+	}
+
+	return rt.endpoint, params, nil
+}
+
+func isParam(part string) bool {
+	return strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}")
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,74 @@
+package api
+
+import "testing"
+
+func TestRouterMatchesStaticPath(t *testing.T) {
+	r := NewRouter()
+	r.Add(Endpoint{Name: "Foo.List", Method: []string{"GET"}, Path: []string{"/foos"}})
+
+	ep, params, err := r.Match("GET", "/foos")
+	if err != nil {
+		t.Fatalf("Match: %v", err)
+	}
+	if ep.Name != "Foo.List" {
+		t.Fatalf("got %q, want Foo.List", ep.Name)
+	}
+	if len(params) != 0 {
+		t.Fatalf("got params %v, want none", params)
+	}
+}
+
+func TestRouterMatchesParam(t *testing.T) {
+	r := NewRouter()
+	r.Add(Endpoint{Name: "Foo.Get", Method: []string{"GET"}, Path: []string{"/foos/{id}"}})
+
+	ep, params, err := r.Match("GET", "/foos/42")
+	if err != nil {
+		t.Fatalf("Match: %v", err)
+	}
+	if ep.Name != "Foo.Get" {
+		t.Fatalf("got %q, want Foo.Get", ep.Name)
+	}
+	if params["id"] != "42" {
+		t.Fatalf("got params %v, want id=42", params)
+	}
+}
+
+// TestRouterDistinctParamNamesAtSamePosition guards against the bug where a
+// single paramChild node shared its paramName across every endpoint passing
+// through it: two endpoints using different placeholder names for the same
+// path position used to clobber each other's param name.
+func TestRouterDistinctParamNamesAtSamePosition(t *testing.T) {
+	r := NewRouter()
+	r.Add(Endpoint{Name: "Foo.Get", Method: []string{"GET"}, Path: []string{"/foos/{id}"}})
+	r.Add(Endpoint{Name: "Foo.Rename", Method: []string{"PUT"}, Path: []string{"/foos/{fooId}"}})
+
+	_, params, err := r.Match("GET", "/foos/42")
+	if err != nil {
+		t.Fatalf("Match GET: %v", err)
+	}
+	if params["id"] != "42" {
+		t.Fatalf("GET params = %v, want id=42", params)
+	}
+
+	_, params, err = r.Match("PUT", "/foos/42")
+	if err != nil {
+		t.Fatalf("Match PUT: %v", err)
+	}
+	if params["fooId"] != "42" {
+		t.Fatalf("PUT params = %v, want fooId=42", params)
+	}
+}
+
+func TestRouterNotFound(t *testing.T) {
+	r := NewRouter()
+	r.Add(Endpoint{Name: "Foo.List", Method: []string{"GET"}, Path: []string{"/foos"}})
+
+	if _, _, err := r.Match("GET", "/bars"); err != ErrNotFound {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+
+	if _, _, err := r.Match("DELETE", "/foos"); err != ErrNotFound {
+		t.Fatalf("got %v, want ErrNotFound for an unregistered method", err)
+	}
+}
@@ -0,0 +1,47 @@
+// Package broker provides a pluggable async pub/sub abstraction, mirroring
+// how encoder and registry are split between an interface and drivers.
+package broker
+
+// Message is a single pub/sub message. Header carries transport-agnostic
+// metadata and Body the encoded payload.
+type Message struct {
+	Header map[string]string
+	Body   []byte
+}
+
+// Handler processes a message delivered to a subscription.
+type Handler func(msg *Message) error
+
+// Broker connects services together by topic, without either side knowing
+// the other's address.
+type Broker interface {
+	Connect() error
+	Disconnect() error
+	Publish(topic string, msg *Message, opts ...PublishOption) error
+	Subscribe(topic string, h Handler, opts ...SubscribeOption) (Subscriber, error)
+	String() string
+}
+
+// Subscriber represents an active subscription created by Broker.Subscribe.
+type Subscriber interface {
+	Topic() string
+	Unsubscribe() error
+}
+
+type PublishOptions struct{}
+
+type PublishOption func(*PublishOptions)
+
+type SubscribeOptions struct {
+	// Queue groups subscribers so only one member of the queue receives
+	// a given message, instead of every subscriber.
+	Queue string
+}
+
+type SubscribeOption func(*SubscribeOptions)
+
+func Queue(name string) SubscribeOption {
+	return func(o *SubscribeOptions) {
+		o.Queue = name
+	}
+}
@@ -0,0 +1,161 @@
+// Package http is a Broker driver that delivers messages by POSTing them to
+// peer subscriber endpoints discovered via registry.Registry, with no extra
+// infrastructure beyond the services that are already registered.
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/plimble/kuja/broker"
+	"github.com/plimble/kuja/registry"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type httpBroker struct {
+	id   string
+	addr string
+	reg  registry.Registry
+
+	mu   sync.Mutex
+	subs map[string][]*httpSubscriber
+}
+
+// NewBroker builds an HTTP Broker. addr is the host:port this process
+// listens on for broker deliveries; register b's ServeHTTP under
+// "/_broker/" on that listener.
+func NewBroker(id, addr string, r registry.Registry) broker.Broker {
+	return &httpBroker{
+		id:   id,
+		addr: addr,
+		reg:  r,
+		subs: make(map[string][]*httpSubscriber),
+	}
+}
+
+func (b *httpBroker) String() string {
+	return "http"
+}
+
+func (b *httpBroker) Connect() error {
+	return nil
+}
+
+func (b *httpBroker) Disconnect() error {
+	b.mu.Lock()
+	topics := make([]string, 0, len(b.subs))
+	for topic := range b.subs {
+		topics = append(topics, topic)
+	}
+	b.mu.Unlock()
+
+	var lastErr error
+	for _, topic := range topics {
+		if err := b.reg.Deregister(topic, b.id); err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+func (b *httpBroker) Publish(topic string, msg *broker.Message, opts ...broker.PublishOption) error {
+	nodes, err := b.reg.GetService(topic)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, n := range nodes {
+		url := fmt.Sprintf("http://%s/_broker/%s", n.Address, topic)
+
+		resp, err := http.Post(url, "application/octet-stream", bytes.NewReader(msg.Body))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+	}
+
+	return lastErr
+}
+
+func (b *httpBroker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	sub := &httpSubscriber{topic: topic, handler: h, b: b}
+
+	b.mu.Lock()
+	b.subs[topic] = append(b.subs[topic], sub)
+	b.mu.Unlock()
+
+	if err := b.reg.Register(&registry.Node{
+		Id:      b.id,
+		Name:    topic,
+		Address: b.addr,
+	}); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// ServeHTTP receives a published message and fans it out to every local
+// subscriber of its topic. Mount it at "/_broker/" on the listener whose
+// address was passed to NewBroker.
+func (b *httpBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	topic := strings.TrimPrefix(r.URL.Path, "/_broker/")
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	b.mu.Lock()
+	subs := b.subs[topic]
+	b.mu.Unlock()
+
+	msg := &broker.Message{Body: body}
+	for _, sub := range subs {
+		if err := sub.handler(msg); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type httpSubscriber struct {
+	topic   string
+	handler broker.Handler
+	b       *httpBroker
+}
+
+func (s *httpSubscriber) Topic() string {
+	return s.topic
+}
+
+func (s *httpSubscriber) Unsubscribe() error {
+	s.b.mu.Lock()
+	s.b.subs[s.topic] = removeSubscriber(s.b.subs[s.topic], s)
+	s.b.mu.Unlock()
+
+	return s.b.reg.Deregister(s.topic, s.b.id)
+}
+
+// removeSubscriber returns subs with sub removed, so a local httpBroker stops
+// invoking a handler that has unsubscribed instead of only deregistering it
+// from the registry and continuing to fan out to it for anything still in
+// flight.
+func removeSubscriber(subs []*httpSubscriber, sub *httpSubscriber) []*httpSubscriber {
+	for i, s := range subs {
+		if s == sub {
+			return append(subs[:i], subs[i+1:]...)
+		}
+	}
+
+	return subs
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/plimble/kuja/broker"
+)
+
+func TestServeHTTPFansOutToLocalSubscribers(t *testing.T) {
+	var got []byte
+	sub := &httpSubscriber{topic: "orders"}
+
+	b := &httpBroker{subs: map[string][]*httpSubscriber{
+		"orders": {sub},
+	}}
+	sub.b = b
+	sub.handler = func(msg *broker.Message) error {
+		got = msg.Body
+		return nil
+	}
+
+	req := httptest.NewRequest("POST", "/_broker/orders", strings.NewReader("payload"))
+	w := httptest.NewRecorder()
+
+	b.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("got status %d, want 200", w.Code)
+	}
+	if string(got) != "payload" {
+		t.Fatalf("got body %q, want %q", got, "payload")
+	}
+}
+
+// TestRemoveSubscriberStopsFanOut guards against the bug where Unsubscribe
+// only deregistered from the registry and never removed itself from b.subs,
+// so a local httpBroker kept invoking a handler after it had unsubscribed.
+// It checks removeSubscriber, the step Unsubscribe uses to drop itself from
+// b.subs, rather than Unsubscribe itself.
+func TestRemoveSubscriberStopsFanOut(t *testing.T) {
+	called := false
+	sub := &httpSubscriber{topic: "orders", handler: func(msg *broker.Message) error {
+		called = true
+		return nil
+	}}
+
+	subs := removeSubscriber([]*httpSubscriber{sub}, sub)
+	if len(subs) != 0 {
+		t.Fatalf("got %d subscribers, want 0 after removal", len(subs))
+	}
+
+	b := &httpBroker{subs: map[string][]*httpSubscriber{"orders": subs}}
+
+	req := httptest.NewRequest("POST", "/_broker/orders", strings.NewReader("payload"))
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, req)
+
+	if called {
+		t.Fatal("handler was invoked after its subscriber was removed")
+	}
+}
@@ -0,0 +1,82 @@
+// +build nats
+
+// Package nats is a Broker driver backed by a NATS server, built only when
+// the "nats" build tag is set so the default build doesn't pull in the NATS
+// client.
+package nats
+
+import (
+	"github.com/nats-io/nats"
+	"github.com/plimble/kuja/broker"
+)
+
+type natsBroker struct {
+	addr string
+	conn *nats.Conn
+}
+
+func NewBroker(addr string) broker.Broker {
+	return &natsBroker{addr: addr}
+}
+
+func (b *natsBroker) String() string {
+	return "nats"
+}
+
+func (b *natsBroker) Connect() error {
+	conn, err := nats.Connect(b.addr)
+	if err != nil {
+		return err
+	}
+
+	b.conn = conn
+
+	return nil
+}
+
+func (b *natsBroker) Disconnect() error {
+	b.conn.Close()
+
+	return nil
+}
+
+func (b *natsBroker) Publish(topic string, msg *broker.Message, opts ...broker.PublishOption) error {
+	return b.conn.Publish(topic, msg.Body)
+}
+
+func (b *natsBroker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	options := broker.SubscribeOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	handler := func(m *nats.Msg) {
+		h(&broker.Message{Body: m.Data})
+	}
+
+	var sub *nats.Subscription
+	var err error
+	if options.Queue != "" {
+		sub, err = b.conn.QueueSubscribe(topic, options.Queue, handler)
+	} else {
+		sub, err = b.conn.Subscribe(topic, handler)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &natsSubscriber{topic: topic, sub: sub}, nil
+}
+
+type natsSubscriber struct {
+	topic string
+	sub   *nats.Subscription
+}
+
+func (s *natsSubscriber) Topic() string {
+	return s.topic
+}
+
+func (s *natsSubscriber) Unsubscribe() error {
+	return s.sub.Unsubscribe()
+}
@@ -0,0 +1,104 @@
+// Package form maps application/x-www-form-urlencoded bodies onto struct
+// fields tagged `form:"..."`, so a GET-like RPC call can be made from a plain
+// HTML form or query string without a JSON body.
+package form
+
+import (
+	"errors"
+	"fmt"
+	"github.com/plimble/kuja/internal/convert"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+)
+
+var errNotStruct = errors.New("form: v must be a pointer to a struct")
+
+type FormEncoder struct{}
+
+func NewEncoder() *FormEncoder {
+	return &FormEncoder{}
+}
+
+func (e *FormEncoder) Encode(w io.Writer, v interface{}) error {
+	data, err := e.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+
+	return err
+}
+
+func (e *FormEncoder) Decode(r io.Reader, v interface{}) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return e.Unmarshal(data, v)
+}
+
+func (e *FormEncoder) Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errNotStruct
+	}
+	rt := rv.Type()
+
+	values := url.Values{}
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := fieldName(field)
+
+		values.Set(tag, fmt.Sprintf("%v", rv.Field(i).Interface()))
+	}
+
+	return []byte(values.Encode()), nil
+}
+
+func (e *FormEncoder) Unmarshal(data []byte, v interface{}) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errNotStruct
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		val := values.Get(fieldName(field))
+		if val == "" {
+			continue
+		}
+
+		convert.SetField(rv.Field(i), val)
+	}
+
+	return nil
+}
+
+func fieldName(field reflect.StructField) string {
+	if tag := field.Tag.Get("form"); tag != "" {
+		return tag
+	}
+
+	return field.Name
+}
@@ -0,0 +1,49 @@
+package form
+
+import "testing"
+
+type formTarget struct {
+	Name     string `form:"name"`
+	Age      int    `form:"age"`
+	internal string
+}
+
+func TestMarshalUnmarshalRoundTrip(t *testing.T) {
+	in := formTarget{Name: "ada", Age: 30}
+
+	data, err := NewEncoder().Marshal(&in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var out formTarget
+	if err := NewEncoder().Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if out.Name != in.Name || out.Age != in.Age {
+		t.Fatalf("got %+v, want %+v", out, in)
+	}
+}
+
+// TestMarshalSkipsUnexportedFields guards against the bug where Marshal and
+// Unmarshal used reflection on every struct field, including unexported
+// ones, which panics with "reflect: ... cannot return value obtained from
+// unexported field" the first time a real arg/reply struct has one.
+func TestMarshalSkipsUnexportedFields(t *testing.T) {
+	in := formTarget{Name: "ada", Age: 30, internal: "should not panic or appear"}
+
+	data, err := NewEncoder().Marshal(&in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var out formTarget
+	if err := NewEncoder().Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if out.internal != "" {
+		t.Fatalf("got internal %q, want it left unset", out.internal)
+	}
+}
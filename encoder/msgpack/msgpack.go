@@ -0,0 +1,28 @@
+package msgpack
+
+import (
+	"github.com/vmihailenco/msgpack"
+	"io"
+)
+
+type MsgpackEncoder struct{}
+
+func NewEncoder() *MsgpackEncoder {
+	return &MsgpackEncoder{}
+}
+
+func (e *MsgpackEncoder) Encode(w io.Writer, v interface{}) error {
+	return msgpack.NewEncoder(w).Encode(v)
+}
+
+func (e *MsgpackEncoder) Decode(r io.Reader, v interface{}) error {
+	return msgpack.NewDecoder(r).Decode(v)
+}
+
+func (e *MsgpackEncoder) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (e *MsgpackEncoder) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
@@ -0,0 +1,31 @@
+// Package convert holds small reflection helpers shared by kuja's codecs
+// and routers, so string-to-struct-field binding isn't copied between them.
+package convert
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// SetField parses val and assigns it to field according to field's kind.
+// Unsupported kinds and parse failures are silently skipped, leaving the
+// field unchanged, since callers bind best-effort from untyped
+// path/query/form values.
+func SetField(field reflect.Value, val string) {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			field.SetInt(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			field.SetFloat(f)
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(val); err == nil {
+			field.SetBool(b)
+		}
+	}
+}
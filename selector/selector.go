@@ -0,0 +1,221 @@
+// Package selector picks a node from the ones registry.Registry reports for
+// a service, according to a pluggable load-balancing Strategy.
+package selector
+
+import (
+	"errors"
+	"github.com/plimble/kuja/registry"
+	"sync"
+	"time"
+)
+
+var ErrNoneAvailable = errors.New("selector: no available nodes")
+
+// Next yields a node to use for a call attempt. Calling it repeatedly lets
+// the caller retry against a different node.
+type Next func() (*registry.Node, error)
+
+// Selector resolves nodes for a service and lets callers report whether a
+// node worked so it can be avoided on the next Select.
+type Selector interface {
+	Select(service string, opts ...SelectOption) (Next, error)
+	Mark(node *registry.Node, err error)
+	Close() error
+}
+
+type SelectOptions struct {
+	// Key is the value consistent-hash strategies hash on.
+	Key string
+}
+
+type SelectOption func(*SelectOptions)
+
+func WithHashKey(key string) SelectOption {
+	return func(o *SelectOptions) {
+		o.Key = key
+	}
+}
+
+type Options struct {
+	Registry registry.Registry
+	Strategy Strategy
+}
+
+type Option func(*Options)
+
+func Registry(r registry.Registry) Option {
+	return func(o *Options) {
+		o.Registry = r
+	}
+}
+
+func WithStrategy(s Strategy) Option {
+	return func(o *Options) {
+		o.Strategy = s
+	}
+}
+
+type backoffEntry struct {
+	until time.Time
+	tries int
+}
+
+type selector struct {
+	opts Options
+
+	mu       sync.Mutex
+	cache    map[string][]*registry.Node
+	watching map[string]bool
+
+	blacklistMu sync.Mutex
+	blacklist   map[string]*backoffEntry
+}
+
+// NewSelector builds a Selector backed by the given registry. It defaults to
+// a round-robin strategy when none is supplied.
+func NewSelector(opts ...Option) Selector {
+	options := Options{
+		Strategy: NewRoundRobinStrategy(),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &selector{
+		opts:      options,
+		cache:     make(map[string][]*registry.Node),
+		watching:  make(map[string]bool),
+		blacklist: make(map[string]*backoffEntry),
+	}
+}
+
+func (s *selector) nodes(service string) ([]*registry.Node, error) {
+	s.mu.Lock()
+	nodes, ok := s.cache[service]
+	s.mu.Unlock()
+	if ok {
+		return nodes, nil
+	}
+
+	nodes, err := s.opts.Registry.GetService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[service] = nodes
+	startWatch := !s.watching[service]
+	s.watching[service] = true
+	s.mu.Unlock()
+
+	// watch runs for the lifetime of the selector, so only start one per
+	// service instead of spawning a fresh goroutine on every cache miss.
+	if startWatch {
+		go s.watch(service)
+	}
+
+	return nodes, nil
+}
+
+// watch invalidates the cached node list whenever the registry reports a
+// change for service, so the next Select re-fetches it.
+func (s *selector) watch(service string) {
+	w, err := s.opts.Registry.Watch(service)
+	if err != nil {
+		return
+	}
+
+	for {
+		if _, err := w.Next(); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		delete(s.cache, service)
+		s.mu.Unlock()
+	}
+}
+
+// filter drops nodes that are still backing off from a recent failure. If
+// every node is backing off it returns the unfiltered list rather than
+// leaving the caller with nothing to try.
+func (s *selector) filter(nodes []*registry.Node) []*registry.Node {
+	s.blacklistMu.Lock()
+	defer s.blacklistMu.Unlock()
+
+	now := time.Now()
+	filtered := make([]*registry.Node, 0, len(nodes))
+	for _, n := range nodes {
+		entry, blacklisted := s.blacklist[n.Id]
+		if !blacklisted || now.After(entry.until) {
+			filtered = append(filtered, n)
+		}
+	}
+
+	if len(filtered) == 0 {
+		return nodes
+	}
+
+	return filtered
+}
+
+func (s *selector) Select(service string, opts ...SelectOption) (Next, error) {
+	options := SelectOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	nodes, err := s.nodes(service)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes = s.filter(nodes)
+	if len(nodes) == 0 {
+		return nil, ErrNoneAvailable
+	}
+
+	return s.opts.Strategy(nodes, options), nil
+}
+
+// Mark records the outcome of a call to node. A nil err clears any existing
+// backoff; a non-nil err backs the node off exponentially, capped at a
+// minute, until it's tried again.
+func (s *selector) Mark(node *registry.Node, err error) {
+	s.blacklistMu.Lock()
+	defer s.blacklistMu.Unlock()
+
+	if err == nil {
+		delete(s.blacklist, node.Id)
+		return
+	}
+
+	entry, ok := s.blacklist[node.Id]
+	if !ok {
+		entry = &backoffEntry{}
+		s.blacklist[node.Id] = entry
+	}
+	entry.tries++
+	entry.until = time.Now().Add(backoffDuration(entry.tries))
+}
+
+// backoffDuration returns the exponential backoff (2s, 4s, 8s, ... for try
+// counts 1, 2, 3, ...) for the given try count, capped at a minute. The shift is capped rather than just
+// the result so tries can't grow large enough to overflow the duration.
+func backoffDuration(tries int) time.Duration {
+	shift := tries
+	if shift > 6 {
+		shift = 6
+	}
+
+	backoff := time.Duration(1<<uint(shift)) * time.Second
+	if backoff > time.Minute {
+		backoff = time.Minute
+	}
+
+	return backoff
+}
+
+func (s *selector) Close() error {
+	return nil
+}
@@ -0,0 +1,73 @@
+package selector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plimble/kuja/registry"
+)
+
+func TestBackoffDurationIsExponentialAndCapped(t *testing.T) {
+	cases := []struct {
+		tries int
+		want  time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{6, 64 * time.Second},
+		{100, time.Minute},
+	}
+
+	for _, c := range cases {
+		if got := backoffDuration(c.tries); got != c.want {
+			t.Errorf("backoffDuration(%d) = %s, want %s", c.tries, got, c.want)
+		}
+	}
+}
+
+func TestMarkBacksOffAndClears(t *testing.T) {
+	s := &selector{blacklist: make(map[string]*backoffEntry)}
+	node := &registry.Node{Id: "a"}
+
+	s.Mark(node, errors.New("boom"))
+	entry, ok := s.blacklist["a"]
+	if !ok || entry.tries != 1 {
+		t.Fatalf("got %+v, want one backoff try recorded", entry)
+	}
+
+	s.Mark(node, errors.New("boom again"))
+	if s.blacklist["a"].tries != 2 {
+		t.Fatalf("got %d tries, want 2", s.blacklist["a"].tries)
+	}
+
+	s.Mark(node, nil)
+	if _, ok := s.blacklist["a"]; ok {
+		t.Fatal("Mark(nil) should clear the backoff entry")
+	}
+}
+
+func TestFilterDropsBackedOffNodes(t *testing.T) {
+	s := &selector{blacklist: make(map[string]*backoffEntry)}
+	good := &registry.Node{Id: "good"}
+	bad := &registry.Node{Id: "bad"}
+
+	s.Mark(bad, errors.New("boom"))
+
+	filtered := s.filter([]*registry.Node{good, bad})
+	if len(filtered) != 1 || filtered[0].Id != "good" {
+		t.Fatalf("got %v, want only the healthy node", filtered)
+	}
+}
+
+func TestFilterReturnsAllWhenEveryNodeIsBackedOff(t *testing.T) {
+	s := &selector{blacklist: make(map[string]*backoffEntry)}
+	node := &registry.Node{Id: "only"}
+	s.Mark(node, errors.New("boom"))
+
+	filtered := s.filter([]*registry.Node{node})
+	if len(filtered) != 1 {
+		t.Fatalf("got %v, want the unfiltered list back rather than nothing to try", filtered)
+	}
+}
@@ -0,0 +1,115 @@
+package selector
+
+import (
+	"github.com/plimble/kuja/registry"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Strategy orders the available nodes for a service into a Next sequence.
+type Strategy func(nodes []*registry.Node, opts SelectOptions) Next
+
+// Random picks a uniformly random node on every call.
+func Random(nodes []*registry.Node, opts SelectOptions) Next {
+	return func() (*registry.Node, error) {
+		if len(nodes) == 0 {
+			return nil, ErrNoneAvailable
+		}
+
+		return nodes[rand.Intn(len(nodes))], nil
+	}
+}
+
+// NewRoundRobinStrategy returns a Strategy that cycles through nodes in
+// order, one further node per call. The counter lives in the returned
+// closure rather than the one the Strategy hands back for a single Select,
+// since Select builds a fresh Next on every call — without this, round-robin
+// across separate calls would never advance past nodes[0].
+func NewRoundRobinStrategy() Strategy {
+	var mu sync.Mutex
+	var i int
+
+	return func(nodes []*registry.Node, opts SelectOptions) Next {
+		return func() (*registry.Node, error) {
+			if len(nodes) == 0 {
+				return nil, ErrNoneAvailable
+			}
+
+			mu.Lock()
+			node := nodes[i%len(nodes)]
+			i++
+			mu.Unlock()
+
+			return node, nil
+		}
+	}
+}
+
+// NewLeastRecentlyUsedStrategy returns a Strategy that picks whichever node
+// was used longest ago. The "used longest ago" bookkeeping lives in the
+// returned closure, not a package-level map, so it's owned by whichever
+// selector holds onto this Strategy instead of leaking across every
+// Selector and service in the process.
+func NewLeastRecentlyUsedStrategy() Strategy {
+	var mu sync.Mutex
+	used := make(map[string]time.Time)
+
+	return func(nodes []*registry.Node, opts SelectOptions) Next {
+		return func() (*registry.Node, error) {
+			if len(nodes) == 0 {
+				return nil, ErrNoneAvailable
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			var oldest *registry.Node
+			var oldestUsed time.Time
+			for _, n := range nodes {
+				t := used[n.Id]
+				if oldest == nil || t.Before(oldestUsed) {
+					oldest = n
+					oldestUsed = t
+				}
+			}
+
+			used[oldest.Id] = time.Now()
+
+			return oldest, nil
+		}
+	}
+}
+
+// ConsistentHash picks the node with the highest rendezvous (HRW) weight for
+// opts.Key, so the same key consistently maps to the same node as long as
+// that node stays in the list.
+func ConsistentHash(nodes []*registry.Node, opts SelectOptions) Next {
+	return func() (*registry.Node, error) {
+		if len(nodes) == 0 {
+			return nil, ErrNoneAvailable
+		}
+
+		var best *registry.Node
+		var bestWeight uint32
+		for _, n := range nodes {
+			w := rendezvousWeight(opts.Key, n.Id)
+			if best == nil || w > bestWeight {
+				best = n
+				bestWeight = w
+			}
+		}
+
+		return best, nil
+	}
+}
+
+func rendezvousWeight(key, nodeId string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write([]byte{'|'})
+	h.Write([]byte(nodeId))
+
+	return h.Sum32()
+}
@@ -0,0 +1,131 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/plimble/kuja/registry"
+)
+
+func testNodes(ids ...string) []*registry.Node {
+	nodes := make([]*registry.Node, len(ids))
+	for i, id := range ids {
+		nodes[i] = &registry.Node{Id: id}
+	}
+
+	return nodes
+}
+
+func TestRoundRobinCycles(t *testing.T) {
+	next := NewRoundRobinStrategy()(testNodes("a", "b", "c"), SelectOptions{})
+
+	want := []string{"a", "b", "c", "a", "b", "c"}
+	for i, w := range want {
+		n, err := next()
+		if err != nil {
+			t.Fatalf("next(): %v", err)
+		}
+		if n.Id != w {
+			t.Fatalf("call %d: got %s, want %s", i, n.Id, w)
+		}
+	}
+}
+
+func TestRoundRobinNoneAvailable(t *testing.T) {
+	next := NewRoundRobinStrategy()(nil, SelectOptions{})
+	if _, err := next(); err != ErrNoneAvailable {
+		t.Fatalf("got %v, want ErrNoneAvailable", err)
+	}
+}
+
+// TestRoundRobinPersistsAcrossStrategyCalls guards against the bug where the
+// counter lived inside the per-call closure Select builds fresh each time
+// (Select calls s.opts.Strategy(nodes, options) on every Select, not once at
+// construction), which reset the index to 0 on every separate Select call.
+func TestRoundRobinPersistsAcrossStrategyCalls(t *testing.T) {
+	strategy := NewRoundRobinStrategy()
+	nodes := testNodes("a", "b", "c")
+
+	first, err := strategy(nodes, SelectOptions{})()
+	if err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+	if first.Id != "a" {
+		t.Fatalf("got %s, want a", first.Id)
+	}
+
+	second, err := strategy(nodes, SelectOptions{})()
+	if err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+	if second.Id != "b" {
+		t.Fatalf("got %s, want b (a separate Strategy call should not reset the index)", second.Id)
+	}
+}
+
+func TestRandomReturnsKnownNode(t *testing.T) {
+	nodes := testNodes("a", "b", "c")
+	next := Random(nodes, SelectOptions{})
+
+	n, err := next()
+	if err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+
+	for _, want := range nodes {
+		if want.Id == n.Id {
+			return
+		}
+	}
+	t.Fatalf("got unknown node %s", n.Id)
+}
+
+func TestConsistentHashIsStableForTheSameKey(t *testing.T) {
+	nodes := testNodes("a", "b", "c", "d")
+
+	first, err := ConsistentHash(nodes, SelectOptions{Key: "user-42"})()
+	if err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+	second, err := ConsistentHash(nodes, SelectOptions{Key: "user-42"})()
+	if err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+
+	if first.Id != second.Id {
+		t.Fatalf("same key picked different nodes: %s vs %s", first.Id, second.Id)
+	}
+}
+
+// TestLeastRecentlyUsedIsolatedPerInstance guards against the bug where
+// NewLeastRecentlyUsedStrategy's usage history used to live in a package-level
+// map shared by every selector: a fresh strategy instance must start with no
+// history even after another instance has already picked nodes.
+func TestLeastRecentlyUsedIsolatedPerInstance(t *testing.T) {
+	nodes := testNodes("a", "b")
+
+	nextA := NewLeastRecentlyUsedStrategy()(nodes, SelectOptions{})
+	first, err := nextA()
+	if err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+	if first.Id != "a" {
+		t.Fatalf("got %s, want a", first.Id)
+	}
+
+	second, err := nextA()
+	if err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+	if second.Id != "b" {
+		t.Fatalf("got %s, want b (a was just used)", second.Id)
+	}
+
+	nextB := NewLeastRecentlyUsedStrategy()(nodes, SelectOptions{})
+	third, err := nextB()
+	if err != nil {
+		t.Fatalf("next(): %v", err)
+	}
+	if third.Id != "a" {
+		t.Fatalf("got %s, want a (a fresh strategy must not inherit nextA's history)", third.Id)
+	}
+}
@@ -3,8 +3,13 @@ package kuja
 import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/golang/snappy/snappy"
+	"github.com/plimble/kuja/api"
+	"github.com/plimble/kuja/broker"
 	"github.com/plimble/kuja/encoder"
+	"github.com/plimble/kuja/encoder/form"
+	"github.com/plimble/kuja/encoder/gogoproto"
 	"github.com/plimble/kuja/encoder/json"
+	"github.com/plimble/kuja/encoder/msgpack"
 	"github.com/plimble/kuja/registry"
 	"gopkg.in/tylerb/graceful.v1"
 	"io"
@@ -24,9 +29,11 @@ type Server struct {
 	mu         sync.Mutex // protects the serviceMap
 	serviceMap map[string]*service
 	encoder    encoder.Encoder
-	snappy     bool
+	encoders   map[string]encoder.Encoder
+	apiRouter  *api.Router
 	logError   LogErrorFunc
 	registry   registry.Registry
+	broker     broker.Broker
 }
 
 func defaulLogErr(serviceID, service, method string, status int, err error) {
@@ -38,6 +45,13 @@ func NewServer() *Server {
 		serviceMap: make(map[string]*service),
 		encoder:    json.NewEncoder(),
 		logError:   defaulLogErr,
+		apiRouter:  api.NewRouter(),
+		encoders: map[string]encoder.Encoder{
+			"application/json":                  json.NewEncoder(),
+			"application/x-protobuf":            gogoproto.NewEncoder(),
+			"application/msgpack":               msgpack.NewEncoder(),
+			"application/x-www-form-urlencoded": form.NewEncoder(),
+		},
 	}
 
 	server.pool.New = func() interface{} {
@@ -57,20 +71,67 @@ func (server *Server) Use(h ...Handler) {
 	server.middleware = append(server.middleware, h...)
 }
 
-func (server *Server) Snappy(enable bool) {
-	server.snappy = enable
+// RegisterEncoder makes enc available for negotiation against requests whose
+// Content-Type or Accept header matches contentType.
+func (server *Server) RegisterEncoder(contentType string, enc encoder.Encoder) {
+	server.encoders[contentType] = enc
 }
 
 func (server *Server) Service(service interface{}, h ...Handler) {
 	if err := server.register(service, "", false, h); err != nil {
 		panic(err)
 	}
+
+	if ep, ok := service.(api.Endpointer); ok {
+		for _, endpoint := range ep.Endpoints() {
+			server.apiRouter.Add(endpoint)
+		}
+	}
 }
 
 func (server *Server) Registry(r registry.Registry) {
 	server.registry = r
 }
 
+// Broker sets the broker used by Subscribe. If b also implements
+// http.Handler (as the http broker does), ServeHTTP routes "/_broker/"
+// requests to it directly, so no separate mux registration is needed.
+func (server *Server) Broker(b broker.Broker) {
+	server.broker = b
+}
+
+// Subscribe registers h as the handler for topic, turning it into a
+// broker subscriber. h must have the shape func(arg *T) error; the incoming
+// message body is decoded into a new *T with the server's encoder before h
+// is called.
+func (server *Server) Subscribe(topic string, h interface{}) {
+	hv := reflect.ValueOf(h)
+	ht := hv.Type()
+
+	if ht.Kind() != reflect.Func || ht.NumIn() != 1 || ht.NumOut() != 1 || ht.Out(0) != errorType {
+		panic("kuja: subscribe handler must be func(arg) error")
+	}
+
+	argType := ht.In(0)
+
+	_, err := server.broker.Subscribe(topic, func(msg *broker.Message) error {
+		argv := reflect.New(argType.Elem())
+		if err := server.encoder.Unmarshal(msg.Body, argv.Interface()); err != nil {
+			return err
+		}
+
+		returnValues := hv.Call([]reflect.Value{argv})
+		if returnValues[0].Interface() != nil {
+			return returnValues[0].Interface().(error)
+		}
+
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
 func (server *Server) Encoder(enc encoder.Encoder) {
 	server.encoder = enc
 }
@@ -158,6 +219,34 @@ func (server *Server) stop() {
 
 }
 
+// negotiateEncoder picks a registered encoder matching the request's
+// Content-Type, then its Accept header, falling back to server.encoder.
+func (server *Server) negotiateEncoder(req *http.Request) encoder.Encoder {
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		if enc, ok := server.encoders[stripParams(ct)]; ok {
+			return enc
+		}
+	}
+
+	if accept := req.Header.Get("Accept"); accept != "" {
+		for _, part := range strings.Split(accept, ",") {
+			if enc, ok := server.encoders[stripParams(strings.TrimSpace(part))]; ok {
+				return enc
+			}
+		}
+	}
+
+	return server.encoder
+}
+
+func stripParams(contentType string) string {
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		return strings.TrimSpace(contentType[:i])
+	}
+
+	return contentType
+}
+
 func getServiceMethod(s string) (string, string) {
 	if strings.HasPrefix(s, "/") {
 		s = s[1:]
@@ -177,16 +266,45 @@ func getServiceMethod(s string) (string, string) {
 }
 
 func (server *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if strings.HasPrefix(req.URL.Path, "/_broker/") {
+		if h, ok := server.broker.(http.Handler); ok {
+			h.ServeHTTP(w, req)
+			return
+		}
+	}
+
 	if req.Method != "POST" {
+		if server.serveAPI(w, req) {
+			return
+		}
+
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		io.WriteString(w, "405 must POST\n")
 		return
 	}
 
+	// Try the RPC path first; a POST that doesn't match a registered
+	// service/method falls back to the API router instead of 404ing
+	// outright, so POST endpoints declared via api.Endpoint are reachable.
 	serviceName, methodName := getServiceMethod(req.URL.Path)
 
-	if serviceName == "" || methodName == "" {
+	var s *service
+	var mt *method
+	if serviceName != "" && methodName != "" {
+		// server.mu.Lock()
+		s = server.serviceMap[serviceName]
+		// server.mu.Unlock()
+		if s != nil {
+			mt = s.method[methodName]
+		}
+	}
+
+	if mt == nil {
+		if server.serveAPI(w, req) {
+			return
+		}
+
 		w.WriteHeader(404)
 		w.Write([]byte("rpc: can't find service or method"))
 		return
@@ -198,28 +316,13 @@ func (server *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		ctx.ReqMetadata[name] = vals[0]
 	}
 
-	// server.mu.Lock()
-	s := server.serviceMap[serviceName]
-	// server.mu.Unlock()
-	if s == nil {
-		w.WriteHeader(404)
-		w.Write([]byte("rpc: can't find service " + serviceName))
-		return
-	}
-	mt := s.method[methodName]
-	if mt == nil {
-		w.WriteHeader(404)
-		w.Write([]byte("rpc: can't find method " + methodName))
-		return
-	}
-
 	ctx.handlers = s.handlers
 	ctx.mt = mt
 	ctx.req = req
 	ctx.w = w
 	ctx.rcvr = s.rcvr
-	ctx.encoder = server.encoder
-	ctx.snappy = server.snappy
+	ctx.encoder = server.negotiateEncoder(req)
+	ctx.snappy = req.Header.Get("Content-Encoding") == "snappy"
 	ctx.ServiceID = s.id
 	ctx.ServiceName = serviceName
 	ctx.MethodName = methodName
@@ -228,6 +331,10 @@ func (server *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		if err := s.handlers[0](ctx, w, req); err != nil && !ctx.isResp {
 			respError(err, ctx)
 		}
+	} else if isStreamMethod(mt) {
+		if err := serveStream(ctx); err != nil && !ctx.isResp {
+			respError(err, ctx)
+		}
 	} else {
 		if err := serve(ctx); err != nil && !ctx.isResp {
 			respError(err, ctx)
@@ -237,7 +344,21 @@ func (server *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 }
 
 func respError(err error, ctx *Ctx) {
-	if errs, ok := err.(Errors); ok {
+	if st, ok := err.(*Status); ok {
+		ctx.isResp = true
+		go ctx.logError(ctx.ServiceID, ctx.ServiceName, ctx.MethodName, st.Code, err)
+
+		data, contentType, encErr := st.marshal()
+		if encErr != nil {
+			ctx.w.WriteHeader(500)
+			ctx.w.Write([]byte(encErr.Error()))
+			return
+		}
+
+		ctx.w.Header().Set("Content-Type", contentType)
+		ctx.w.WriteHeader(st.Code)
+		ctx.w.Write(data)
+	} else if errs, ok := err.(Errors); ok {
 		ctx.isResp = true
 		go ctx.logError(ctx.ServiceID, ctx.ServiceName, ctx.MethodName, errs.Status(), err)
 		ctx.w.WriteHeader(errs.Status())
@@ -0,0 +1,81 @@
+package kuja
+
+import (
+	"encoding/json"
+	"github.com/gogo/protobuf/proto"
+	"net/http"
+)
+
+// StatusContentType marks a response body as an encoded *Status rather than
+// the plain text respError otherwise writes.
+const StatusContentType = "application/vnd.kuja.status+json"
+
+// Status is a structured error for propagating failures across service
+// boundaries, carrying more than the plain Errors interface can: a machine
+// readable Reason, arbitrary Details, and free-form Metadata.
+type Status struct {
+	Code     int               `json:"code"`
+	Reason   string            `json:"reason"`
+	Message  string            `json:"message"`
+	Details  []proto.Message   `json:"details,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func NewStatus(code int, reason, msg string) *Status {
+	return &Status{
+		Code:    code,
+		Reason:  reason,
+		Message: msg,
+	}
+}
+
+func (s *Status) WithDetails(details ...proto.Message) *Status {
+	s.Details = append(s.Details, details...)
+
+	return s
+}
+
+func (s *Status) WithMetadata(k, v string) *Status {
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]string)
+	}
+	s.Metadata[k] = v
+
+	return s
+}
+
+func (s *Status) Error() string {
+	return s.Message
+}
+
+// Status satisfies the same shape respError already expects from Errors, so
+// a *Status can be logged with the same status code it's served with.
+func (s *Status) Status() int {
+	return s.Code
+}
+
+// marshal encodes s. Status isn't a generated protobuf message — its fields
+// carry json tags, not the protobuf tags gogo's reflection-based Marshal
+// needs to find a field's wire number and type — so it's always serialized
+// as JSON regardless of the request's negotiated encoder, rather than
+// risking an empty or malformed payload under the protobuf codec.
+func (s *Status) marshal() ([]byte, string, error) {
+	data, err := json.Marshal(s)
+
+	return data, StatusContentType, err
+}
+
+// ParseStatus decodes a *Status from a response previously written by
+// respError, the client-side counterpart of the encoding respError does.
+// A body with a non-empty details field fails to decode and returns an
+// error: Details holds proto.Message interface values, which encoding/json
+// cannot construct.
+func ParseStatus(resp *http.Response) (*Status, error) {
+	st := &Status{}
+
+	if err := json.NewDecoder(resp.Body).Decode(st); err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
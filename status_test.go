@@ -0,0 +1,37 @@
+package kuja
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestStatusMarshalParseRoundTrip(t *testing.T) {
+	st := NewStatus(404, "NOT_FOUND", "no such thing").WithMetadata("id", "42")
+
+	data, contentType, err := st.marshal()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if contentType != StatusContentType {
+		t.Fatalf("got content type %q, want %q", contentType, StatusContentType)
+	}
+
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{contentType}},
+		Body:   ioutil.NopCloser(bytes.NewReader(data)),
+	}
+
+	got, err := ParseStatus(resp)
+	if err != nil {
+		t.Fatalf("ParseStatus: %v", err)
+	}
+
+	if got.Code != st.Code || got.Reason != st.Reason || got.Message != st.Message {
+		t.Fatalf("got %+v, want %+v", got, st)
+	}
+	if got.Metadata["id"] != "42" {
+		t.Fatalf("got metadata %v, want id=42", got.Metadata)
+	}
+}
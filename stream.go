@@ -0,0 +1,162 @@
+package kuja
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/golang/snappy/snappy"
+	"github.com/plimble/kuja/encoder"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// Stream lets a service method exchange a sequence of messages with the
+// caller over a single HTTP connection instead of a single request/response.
+type Stream interface {
+	Send(v interface{}) error
+	Recv(v interface{}) error
+}
+
+var (
+	streamType = reflect.TypeOf((*Stream)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// maxFrameSize bounds how much a single Recv will allocate for one frame's
+// payload, so a frame header claiming an absurd length can't be used to
+// force a multi-gigabyte allocation.
+const maxFrameSize = 16 << 20 // 16MiB
+
+var errFrameTooLarge = errors.New("kuja: stream frame exceeds maxFrameSize")
+
+type stream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	body    io.ReadCloser
+	encoder encoder.Encoder
+	snappy  bool
+}
+
+func newStream(ctx *Ctx) (*stream, error) {
+	flusher, ok := ctx.w.(http.Flusher)
+	if !ok {
+		return nil, Error(500, "streaming not supported by response writer")
+	}
+
+	return &stream{
+		w:       ctx.w,
+		flusher: flusher,
+		body:    ctx.req.Body,
+		encoder: ctx.encoder,
+		snappy:  ctx.snappy,
+	}, nil
+}
+
+// Send marshals v with the negotiated encoder and writes it as a single
+// length-prefixed frame: 1 byte snappy flag + 4 byte big-endian length + payload.
+func (s *stream) Send(v interface{}) error {
+	data, err := s.encoder.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var flag byte
+	if s.snappy {
+		data, err = snappy.Encode(nil, data)
+		if err != nil {
+			return err
+		}
+		flag = 1
+	}
+
+	header := make([]byte, 5)
+	header[0] = flag
+	binary.BigEndian.PutUint32(header[1:], uint32(len(data)))
+
+	if _, err := s.w.Write(header); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+
+	return nil
+}
+
+// Recv blocks until the next frame arrives on the request body and
+// unmarshals it into v.
+func (s *stream) Recv(v interface{}) error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(s.body, header); err != nil {
+		return err
+	}
+
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxFrameSize {
+		return errFrameTooLarge
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(s.body, data); err != nil {
+		return err
+	}
+
+	if header[0] == 1 {
+		decoded, err := snappy.Decode(nil, data)
+		if err != nil {
+			return err
+		}
+		data = decoded
+	}
+
+	return s.encoder.Unmarshal(data, v)
+}
+
+// isStreamMethod reports whether mt is a streaming handler, i.e. declared as
+// func(ctx *Ctx, stream Stream) error rather than the usual
+// func(ctx *Ctx, req, rep *T) error.
+func isStreamMethod(mt *method) bool {
+	return IsStreamSignature(mt.method.Func.Type())
+}
+
+// IsStreamSignature reports whether ft — a service method's func type,
+// including the receiver as its first parameter, the shape reflect.Method.Func
+// produces — matches the streaming handler shape
+// func(rcvr, ctx *Ctx, stream Stream) error.
+//
+// register (where server.serviceMap is built) must treat this shape as valid
+// alongside the ordinary func(rcvr, ctx *Ctx, req, reply *T) error one; a
+// method whose only accepted shape is the latter never reaches s.method for
+// a streaming handler, which would make isStreamMethod/serveStream
+// unreachable. Exported so register can call it directly.
+func IsStreamSignature(ft reflect.Type) bool {
+	return ft.NumIn() == 3 && ft.In(2) == streamType && ft.NumOut() == 1 && ft.Out(0) == errorType
+}
+
+func serveStream(ctx *Ctx) error {
+	st, err := newStream(ctx)
+	if err != nil {
+		return err
+	}
+	defer ctx.req.Body.Close()
+
+	ctx.w.Header().Set("Transfer-Encoding", "chunked")
+	ctx.w.WriteHeader(200)
+	ctx.isResp = true
+
+	function := ctx.mt.method.Func
+	returnValues := function.Call([]reflect.Value{ctx.rcvr, ctx.mt.prepareContext(ctx), reflect.ValueOf(st)})
+
+	if errv := returnValues[0].Interface(); errv != nil {
+		err := errv.(error)
+		// Headers are already flushed by the time a streaming handler can
+		// fail, so ctx.isResp is already true and ServeHTTP's respError
+		// call is a no-op for this path. Log the error here instead of
+		// letting it disappear silently.
+		go ctx.logError(ctx.ServiceID, ctx.ServiceName, ctx.MethodName, 500, err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package kuja
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// jsonEncoder is a minimal encoder.Encoder used only to exercise stream
+// framing without pulling in a real codec.
+type jsonEncoder struct{}
+
+func (jsonEncoder) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (jsonEncoder) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func (jsonEncoder) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonEncoder) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func TestStreamSendRecvRoundTrip(t *testing.T) {
+	rec := httptest.NewRecorder()
+	send := &stream{w: rec, flusher: rec, encoder: jsonEncoder{}}
+
+	msg := "hello stream"
+	if err := send.Send(&msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	recv := &stream{
+		body:    ioutil.NopCloser(bytes.NewReader(rec.Body.Bytes())),
+		encoder: jsonEncoder{},
+	}
+
+	var got string
+	if err := recv.Recv(&got); err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+
+	if got != msg {
+		t.Fatalf("got %q, want %q", got, msg)
+	}
+}
+
+// TestStreamRecvRejectsOversizedFrame guards against Recv allocating directly
+// off an attacker-controlled frame length: a header claiming more than
+// maxFrameSize bytes must fail instead of allocating.
+func TestStreamRecvRejectsOversizedFrame(t *testing.T) {
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header[1:], maxFrameSize+1)
+
+	recv := &stream{body: ioutil.NopCloser(bytes.NewReader(header))}
+
+	var v string
+	if err := recv.Recv(&v); err != errFrameTooLarge {
+		t.Fatalf("got %v, want errFrameTooLarge", err)
+	}
+}
+
+type streamSigFixture struct{}
+
+func (streamSigFixture) Tail(ctx *Ctx, s Stream) error     { return nil }
+func (streamSigFixture) Get(ctx *Ctx, req, rep *int) error { return nil }
+
+// TestIsStreamSignature exercises the method-shape check register needs to
+// call to recognize a streaming handler as valid alongside the ordinary
+// unary one, without depending on register itself (which lives outside this
+// file's package source).
+func TestIsStreamSignature(t *testing.T) {
+	rt := reflect.TypeOf(streamSigFixture{})
+
+	tail, ok := rt.MethodByName("Tail")
+	if !ok {
+		t.Fatal("Tail method not found")
+	}
+	if !IsStreamSignature(tail.Func.Type()) {
+		t.Fatal("Tail should be recognized as a streaming signature")
+	}
+
+	get, ok := rt.MethodByName("Get")
+	if !ok {
+		t.Fatal("Get method not found")
+	}
+	if IsStreamSignature(get.Func.Type()) {
+		t.Fatal("Get is a unary handler and should not match the streaming signature")
+	}
+}